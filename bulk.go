@@ -0,0 +1,154 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"sync"
+
+	"github.com/pkg/errors"
+)
+
+// maxBulkWorkers bounds how many requests AreAllowed evaluates concurrently, so a single
+// large batch can't exhaust the process of goroutines or overwhelm downstream matchers.
+const maxBulkWorkers = 16
+
+// BulkManager may be implemented by a Manager to fetch request candidates for several
+// requests at once, e.g. with a single `WHERE subject IN (...)` query. Managers that do not
+// implement it fall back to one FindRequestCandidates call per distinct subject.
+//
+// FindRequestCandidatesBulk must return a Policies slice for every element of rs, in the same
+// order: the i-th entry of the result is the candidate set for rs[i]. AreAllowed rejects a
+// result of the wrong length rather than indexing into it, but a result of the right length
+// that is nonetheless misaligned with rs is not detectable and will silently evaluate the
+// wrong candidates against the wrong request.
+type BulkManager interface {
+	FindRequestCandidatesBulk(ctx context.Context, rs []*Request) ([]Policies, error)
+}
+
+// AreAllowed evaluates many requests against the same manager, returning one error per
+// request (nil meaning allowed, see IsAllowed) plus an error if the batch itself could not
+// be evaluated (e.g. a candidate lookup failed). Requests that share a subject reuse a
+// single FindRequestCandidates (or FindRequestCandidatesBulk) call, and evaluation of the
+// individual requests is parallelized across a bounded worker pool. This is intended for
+// callers such as API gateways that need to check many (resource, action) pairs per
+// incoming call without paying the manager round-trip cost for each one.
+func (l *Ladon) AreAllowed(ctx context.Context, requests []*Request) ([]error, error) {
+	l.lazyInit()
+
+	results := make([]error, len(requests))
+	groups, order := groupRequestsBySubject(requests)
+
+	if bm, ok := l.Manager.(BulkManager); ok {
+		heads := make([]*Request, len(order))
+		for i, subject := range order {
+			heads[i] = requests[groups[subject][0]]
+		}
+
+		candidatesBySubject, err := bm.FindRequestCandidatesBulk(ctx, heads)
+		if err != nil {
+			return nil, err
+		}
+		if len(candidatesBySubject) != len(heads) {
+			return nil, errors.Errorf("BulkManager.FindRequestCandidatesBulk returned %d candidate sets for %d requests", len(candidatesBySubject), len(heads))
+		}
+
+		var wg sync.WaitGroup
+		sem := make(chan struct{}, maxBulkWorkers)
+		for i, subject := range order {
+			l.evaluateGroup(ctx, requests, groups[subject], candidatesBySubject[i], results, &wg, sem)
+		}
+		wg.Wait()
+
+		return results, nil
+	}
+
+	var wg sync.WaitGroup
+	sem := make(chan struct{}, maxBulkWorkers)
+	var firstErr error
+	for _, subject := range order {
+		idxs := groups[subject]
+
+		policies, err := l.Manager.FindRequestCandidates(ctx, requests[idxs[0]])
+		if err != nil {
+			// Don't return yet: groups already dispatched below still have goroutines in
+			// flight, and abandoning them here would leak those goroutines along with the
+			// audit/metric side effects they fire, with no way for the caller to observe
+			// or cancel them. Remember the error, skip this group and keep going so we can
+			// wait for everything dispatched so far before reporting it.
+			if firstErr == nil {
+				firstErr = err
+			}
+			for _, idx := range idxs {
+				results[idx] = err
+			}
+			continue
+		}
+
+		l.evaluateGroup(ctx, requests, idxs, policies, results, &wg, sem)
+	}
+	wg.Wait()
+
+	// Groups that succeeded before firstErr was hit already have their outcomes in results;
+	// discarding them along with the error would leave the caller with no way to tell which
+	// of the other requests in the batch passed or failed.
+	return results, firstErr
+}
+
+// evaluateGroup decides every request index in idxs against the shared candidate list,
+// bounded by sem, writing each outcome into its own slot of results. Each request still goes
+// through the same interceptor chain, DecisionCache and ShadowManager as IsAllowed/Decide -
+// via l.instrumentedDecide wrapping a Handler closed over the already-fetched policies -
+// rather than through Decide itself, which would re-fetch candidates from the Manager and
+// defeat the whole point of sharing one lookup across the group.
+func (l *Ladon) evaluateGroup(ctx context.Context, requests []*Request, idxs []int, policies Policies, results []error, wg *sync.WaitGroup, sem chan struct{}) {
+	handler := l.chain(l.instrumentedDecide(func(ctx context.Context, r *Request) (*Decision, error) {
+		return l.decide(ctx, r, policies)
+	}))
+
+	for _, idx := range idxs {
+		idx := idx
+		wg.Add(1)
+		sem <- struct{}{}
+		go func() {
+			defer wg.Done()
+			defer func() { <-sem }()
+			_, results[idx] = handler(ctx, requests[idx])
+		}()
+	}
+}
+
+// groupRequestsBySubject returns, for each distinct subject, the indices into requests that
+// share it, along with the order in which subjects were first seen (to keep evaluation
+// deterministic).
+func groupRequestsBySubject(requests []*Request) (map[string][]int, []string) {
+	groups := map[string][]int{}
+	var order []string
+
+	for i, r := range requests {
+		if _, ok := groups[r.Subject]; !ok {
+			order = append(order, r.Subject)
+		}
+		groups[r.Subject] = append(groups[r.Subject], i)
+	}
+
+	return groups, order
+}
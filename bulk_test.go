@@ -0,0 +1,163 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"sync"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+// perSubjectFailManager fails FindRequestCandidates for any subject in failFor, and
+// otherwise returns candidates. It records which subjects it was asked about.
+type perSubjectFailManager struct {
+	candidates Policies
+	failFor    map[string]bool
+
+	mu     sync.Mutex
+	called []string
+}
+
+func (m *perSubjectFailManager) Create(Policy) error       { return nil }
+func (m *perSubjectFailManager) Update(Policy) error        { return nil }
+func (m *perSubjectFailManager) Get(string) (Policy, error) { return nil, nil }
+func (m *perSubjectFailManager) Delete(string) error        { return nil }
+func (m *perSubjectFailManager) GetAll(limit, offset int64) (Policies, error) {
+	return m.candidates, nil
+}
+
+func (m *perSubjectFailManager) FindRequestCandidates(ctx context.Context, r *Request) (Policies, error) {
+	m.mu.Lock()
+	m.called = append(m.called, r.Subject)
+	m.mu.Unlock()
+
+	if m.failFor[r.Subject] {
+		return nil, errors.New("candidate lookup failed")
+	}
+	return m.candidates, nil
+}
+
+func TestAreAllowedGroupsBySubject(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	manager := &perSubjectFailManager{candidates: Policies{allow}}
+	l := &Ladon{Manager: manager, Matcher: &fakeMatcher{}, AuditLogger: &fakeAuditLogger{}, Metric: fakeMetric{}, Combiner: DenyOverrides}
+
+	requests := []*Request{
+		{Subject: "peter", Resource: "articles:1", Action: "read"},
+		{Subject: "peter", Resource: "articles:2", Action: "read"},
+		{Subject: "julia", Resource: "articles:1", Action: "read"},
+	}
+
+	results, err := l.AreAllowed(context.Background(), requests)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(results) != 3 {
+		t.Fatalf("len(results) = %d, want 3", len(results))
+	}
+	for i, err := range results {
+		if err != nil {
+			t.Errorf("results[%d] = %v, want nil", i, err)
+		}
+	}
+
+	// peter's two requests must share a single candidate lookup.
+	peterLookups := 0
+	for _, s := range manager.called {
+		if s == "peter" {
+			peterLookups++
+		}
+	}
+	if peterLookups != 1 {
+		t.Fatalf("peter was looked up %d times, want 1", peterLookups)
+	}
+}
+
+func TestAreAllowedWaitsOutInFlightGroupsOnError(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	manager := &perSubjectFailManager{candidates: Policies{allow}, failFor: map[string]bool{"broken": true}}
+	logger := &fakeAuditLogger{}
+	l := &Ladon{Manager: manager, Matcher: &fakeMatcher{}, AuditLogger: logger, Metric: fakeMetric{}, Combiner: DenyOverrides}
+
+	requests := []*Request{
+		{Subject: "peter", Resource: "articles:1", Action: "read"},
+		{Subject: "broken", Resource: "articles:1", Action: "read"},
+	}
+
+	results, err := l.AreAllowed(context.Background(), requests)
+	if err == nil {
+		t.Fatalf("expected an error from the broken subject's candidate lookup")
+	}
+
+	// By the time AreAllowed returns, every goroutine it dispatched for the "peter" group
+	// must have finished (and logged), not be left running in the background.
+	if logger.granted != 1 {
+		t.Fatalf("granted = %d, want 1 (peter's request should have been evaluated and logged before returning)", logger.granted)
+	}
+
+	// The caller gets the batch error, but must not be left blind to the requests that were
+	// successfully evaluated before it: results for peter's group should still be populated.
+	if results == nil || results[0] != nil {
+		t.Fatalf("results = %v, want peter's (already-evaluated) slot to be nil/allowed, not a discarded nil slice", results)
+	}
+}
+
+// bulkManagerWrongLength implements BulkManager but returns fewer candidate sets than it was
+// asked for - a natural implementer mistake, since FindRequestCandidatesBulk's contract is
+// only documented, not enforced by the type system.
+type bulkManagerWrongLength struct {
+	candidates Policies
+}
+
+func (m *bulkManagerWrongLength) Create(Policy) error                     { return nil }
+func (m *bulkManagerWrongLength) Update(Policy) error                      { return nil }
+func (m *bulkManagerWrongLength) Get(string) (Policy, error)               { return nil, nil }
+func (m *bulkManagerWrongLength) Delete(string) error                       { return nil }
+func (m *bulkManagerWrongLength) GetAll(limit, offset int64) (Policies, error) {
+	return m.candidates, nil
+}
+
+func (m *bulkManagerWrongLength) FindRequestCandidates(ctx context.Context, r *Request) (Policies, error) {
+	return m.candidates, nil
+}
+
+func (m *bulkManagerWrongLength) FindRequestCandidatesBulk(ctx context.Context, rs []*Request) ([]Policies, error) {
+	// Two distinct subjects were asked for, but only one candidate set is returned.
+	return []Policies{m.candidates}, nil
+}
+
+func TestAreAllowedRejectsAMisalignedBulkManagerResult(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	manager := &bulkManagerWrongLength{candidates: Policies{allow}}
+	l := &Ladon{Manager: manager, Matcher: &fakeMatcher{}, AuditLogger: &fakeAuditLogger{}, Metric: fakeMetric{}, Combiner: DenyOverrides}
+
+	requests := []*Request{
+		{Subject: "peter", Resource: "articles:1", Action: "read"},
+		{Subject: "julia", Resource: "articles:1", Action: "read"},
+	}
+
+	// Must return an error, not panic with "index out of range".
+	if _, err := l.AreAllowed(context.Background(), requests); err == nil {
+		t.Fatalf("expected an error for a BulkManager result of the wrong length")
+	}
+}
@@ -0,0 +1,201 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// CachedDecision is what a DecisionCache stores: the Decision plus the error IsAllowed/Decide
+// returned alongside it, so that a cache hit reproduces the original outcome exactly.
+type CachedDecision struct {
+	Decision *Decision
+	Err      error
+}
+
+// DecisionCache memoizes Decide results keyed by a canonicalized (subject, resource, action,
+// context) tuple. High-QPS authorizers can use one to avoid re-scanning the Manager on every
+// request. See CachingInterceptor for how it is plumbed into evaluation, and PolicyEvent /
+// SubscribableManager for how cached entries are invalidated when policies change.
+type DecisionCache interface {
+	Get(ctx context.Context, key string) (*CachedDecision, bool)
+	Set(ctx context.Context, key string, decision *CachedDecision)
+
+	// Evict removes every cached decision that may have been affected by a change to p,
+	// e.g. because p's subjects or resources overlap the cached request. Implementations
+	// that cannot determine overlap precisely should fall back to a full flush.
+	Evict(p Policy)
+}
+
+// CacheKey canonicalizes the parts of a Request a cached Decision depends on: subject,
+// resource, action and the request context. Audit/metric side effects and obligations are
+// not part of the key, since they don't affect whether two requests are "the same" decision.
+//
+// Each field is length-prefixed rather than joined with a delimiter, so a subject, resource
+// or action that happens to contain the delimiter byte can't make two distinct requests
+// collide on the same key.
+func CacheKey(r *Request) string {
+	var b strings.Builder
+	writeCacheField(&b, r.Subject)
+	writeCacheField(&b, r.Resource)
+	writeCacheField(&b, r.Action)
+	writeCacheField(&b, contextHash(r.Context))
+	return b.String()
+}
+
+// writeCacheField appends s to b as a netstring-style `<length>:<bytes>` field, so the field
+// can be read back unambiguously regardless of its contents.
+func writeCacheField(b *strings.Builder, s string) {
+	fmt.Fprintf(b, "%d:", len(s))
+	b.WriteString(s)
+}
+
+// readCacheField reads one writeCacheField-encoded field off the front of s, returning it
+// and the remainder of s.
+func readCacheField(s string) (value, rest string, ok bool) {
+	idx := strings.IndexByte(s, ':')
+	if idx < 0 {
+		return "", "", false
+	}
+
+	n, err := strconv.Atoi(s[:idx])
+	if err != nil || n < 0 || idx+1+n > len(s) {
+		return "", "", false
+	}
+
+	return s[idx+1 : idx+1+n], s[idx+1+n:], true
+}
+
+// contextHash produces a stable string representation of a request context, regardless of
+// map iteration order, so equal contexts always yield equal cache keys. Keys and values are
+// length-prefixed for the same collision-avoidance reason as CacheKey's own fields.
+//
+// Each value is also tagged with its Go type, not just its "%v" formatting: without that,
+// Context{"hour": 9} and Context{"hour": "9"} would hash identically even though a condition
+// doing a numeric comparison (e.g. ExpressionCondition's ctx.hour >= 9) evaluates them
+// differently. A cache hit is documented to reproduce the original outcome exactly, so two
+// contexts that could legitimately decide a request differently must never share a key.
+func contextHash(c Context) string {
+	keys := make([]string, 0, len(c))
+	for k := range c {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var b strings.Builder
+	for _, k := range keys {
+		writeCacheField(&b, k)
+		writeCacheField(&b, fmt.Sprintf("%T", c[k]))
+		writeCacheField(&b, fmt.Sprintf("%v", c[k]))
+	}
+	return b.String()
+}
+
+// NoopCache never caches anything; every Get is a miss. It is the default DecisionCache and
+// exists so callers can disable caching (or reset it) without a nil check at every call site.
+var NoopCache DecisionCache = noopCache{}
+
+type noopCache struct{}
+
+func (noopCache) Get(ctx context.Context, key string) (*CachedDecision, bool) { return nil, false }
+func (noopCache) Set(ctx context.Context, key string, decision *CachedDecision) {}
+func (noopCache) Evict(p Policy)                                                {}
+
+// PolicyEventType describes what happened to a policy in PolicyEvent.
+type PolicyEventType string
+
+const (
+	PolicyCreated PolicyEventType = "created"
+	PolicyUpdated PolicyEventType = "updated"
+	PolicyDeleted PolicyEventType = "deleted"
+)
+
+// PolicyEvent is sent on the channel passed to SubscribableManager.Subscribe whenever a
+// policy is created, updated or deleted, so that a DecisionCache can evict the entries it
+// may have invalidated.
+type PolicyEvent struct {
+	Type   PolicyEventType
+	Policy Policy
+}
+
+// SubscribableManager may be implemented by a Manager to notify subscribers of policy
+// changes. A DecisionCache backed by a subscribing Manager stays consistent without callers
+// having to invalidate it by hand.
+type SubscribableManager interface {
+	Subscribe(events chan<- PolicyEvent)
+}
+
+// CachingInterceptor returns an Interceptor that serves decisions from cache when present
+// and stores newly computed decisions back into it otherwise.
+//
+// Every Decision handed out - on both a hit and a miss - is a fresh copy, never the pointer
+// stored in (or about to be stored in) the cache. Decision.Advice and Decision.Obligations
+// are maps, and interceptors registered via Use run outside of CachingInterceptor, so they
+// see and may mutate the Decision after it leaves here; without copying, that mutation would
+// race with concurrent requests sharing the same cache entry and permanently contaminate
+// what every future hit for that key sees.
+func CachingInterceptor(cache DecisionCache) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, r *Request) (*Decision, error) {
+			key := CacheKey(r)
+			if cached, ok := cache.Get(ctx, key); ok {
+				return cloneDecision(cached.Decision), cached.Err
+			}
+
+			decision, err := next(ctx, r)
+			if decision != nil {
+				cache.Set(ctx, key, &CachedDecision{Decision: cloneDecision(decision), Err: err})
+			}
+			return decision, err
+		}
+	}
+}
+
+// cloneDecision makes a deep copy of d so that, once cached, neither the caller nor a later
+// interceptor can mutate the copy held by the cache.
+func cloneDecision(d *Decision) *Decision {
+	if d == nil {
+		return nil
+	}
+
+	clone := *d
+	clone.Deciders = append(Policies{}, d.Deciders...)
+	clone.Conditions = append([]ConditionResult{}, d.Conditions...)
+	clone.Obligations = cloneStringMap(d.Obligations)
+	clone.Advice = cloneStringMap(d.Advice)
+	return &clone
+}
+
+func cloneStringMap(m map[string]interface{}) map[string]interface{} {
+	if m == nil {
+		return nil
+	}
+
+	clone := make(map[string]interface{}, len(m))
+	for k, v := range m {
+		clone[k] = v
+	}
+	return clone
+}
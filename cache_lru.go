@@ -0,0 +1,169 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"container/list"
+	"context"
+	"sync"
+	"time"
+)
+
+// LRUCache is an in-process DecisionCache bounded by both entry count and TTL. Zero value is
+// not usable; construct one with NewLRUCache.
+type LRUCache struct {
+	maxEntries int
+	ttl        time.Duration
+	matcher    matcher
+
+	mu      sync.Mutex
+	entries map[string]*list.Element
+	order   *list.List
+}
+
+type lruEntry struct {
+	key       string
+	decision  *CachedDecision
+	expiresAt time.Time
+}
+
+// NewLRUCache builds an LRUCache holding up to maxEntries decisions, each valid for ttl. If
+// manager implements SubscribableManager, the cache subscribes to its policy events and
+// evicts affected entries automatically; pass nil to manage invalidation manually via Evict.
+// If m is non-nil, it is used to test whether a changed policy's subjects/resources overlap
+// a cached entry; otherwise every policy change flushes the whole cache.
+func NewLRUCache(maxEntries int, ttl time.Duration, manager Manager, m matcher) *LRUCache {
+	c := &LRUCache{
+		maxEntries: maxEntries,
+		ttl:        ttl,
+		matcher:    m,
+		entries:    map[string]*list.Element{},
+		order:      list.New(),
+	}
+
+	if sm, ok := manager.(SubscribableManager); ok {
+		events := make(chan PolicyEvent, 16)
+		sm.Subscribe(events)
+		go func() {
+			for event := range events {
+				c.Evict(event.Policy)
+			}
+		}()
+	}
+
+	return c
+}
+
+// Get implements DecisionCache.
+func (c *LRUCache) Get(ctx context.Context, key string) (*CachedDecision, bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	el, ok := c.entries[key]
+	if !ok {
+		return nil, false
+	}
+
+	entry := el.Value.(*lruEntry)
+	if time.Now().After(entry.expiresAt) {
+		c.removeElementLocked(el)
+		return nil, false
+	}
+
+	c.order.MoveToFront(el)
+	return entry.decision, true
+}
+
+// Set implements DecisionCache.
+func (c *LRUCache) Set(ctx context.Context, key string, decision *CachedDecision) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if el, ok := c.entries[key]; ok {
+		el.Value.(*lruEntry).decision = decision
+		el.Value.(*lruEntry).expiresAt = time.Now().Add(c.ttl)
+		c.order.MoveToFront(el)
+		return
+	}
+
+	el := c.order.PushFront(&lruEntry{key: key, decision: decision, expiresAt: time.Now().Add(c.ttl)})
+	c.entries[key] = el
+
+	for c.order.Len() > c.maxEntries {
+		c.removeElementLocked(c.order.Back())
+	}
+}
+
+// Evict implements DecisionCache. It removes every entry whose subject and resource (parsed
+// back out of its CacheKey) both overlap p, as judged by the matcher passed to
+// NewLRUCache. Without a matcher it flushes the whole cache; an entry for which the matcher
+// returns an error is evicted as well, so a cache entry is never left stale.
+func (c *LRUCache) Evict(p Policy) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.matcher == nil {
+		c.entries = map[string]*list.Element{}
+		c.order.Init()
+		return
+	}
+
+	for key, el := range c.entries {
+		subject, resource := splitCacheKey(key)
+
+		sm, err := c.matcher.Matches(p, p.GetSubjects(), subject)
+		if err != nil {
+			c.removeElementLocked(el)
+			continue
+		}
+
+		rm, err := c.matcher.Matches(p, p.GetResources(), resource)
+		if err != nil {
+			c.removeElementLocked(el)
+			continue
+		}
+
+		if sm && rm {
+			c.removeElementLocked(el)
+		}
+	}
+}
+
+// splitCacheKey recovers the subject and resource encoded in a CacheKey, which length-
+// prefixes each field instead of joining them with a delimiter (see writeCacheField).
+func splitCacheKey(key string) (subject, resource string) {
+	subject, rest, ok := readCacheField(key)
+	if !ok {
+		return "", ""
+	}
+
+	resource, _, ok = readCacheField(rest)
+	if !ok {
+		return "", ""
+	}
+
+	return subject, resource
+}
+
+func (c *LRUCache) removeElementLocked(el *list.Element) {
+	c.order.Remove(el)
+	delete(c.entries, el.Value.(*lruEntry).key)
+}
@@ -0,0 +1,144 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// RedisClient is the minimal subset of a Redis client RedisCache needs, so that this package
+// does not have to depend on any particular Redis driver. Most Redis client libraries'
+// connection/cluster types already satisfy it.
+type RedisClient interface {
+	Set(ctx context.Context, key string, value []byte, ttl time.Duration) error
+	Get(ctx context.Context, key string) ([]byte, error)
+	Keys(ctx context.Context, pattern string) ([]string, error)
+	Del(ctx context.Context, keys ...string) error
+}
+
+// redisCachedDecision is the JSON wire format RedisCache stores. The original error value
+// itself isn't serializable, so ErrKind records which sentinel error decide()/the Combiner
+// returned alongside the Decision, and Get reconstructs the same error from it - a cache hit
+// must not change which sentinel a caller sees compared to a freshly computed decision.
+type redisCachedDecision struct {
+	Effect      Effect                 `json:"effect"`
+	Obligations map[string]interface{} `json:"obligations,omitempty"`
+	ErrKind     string                 `json:"err_kind,omitempty"`
+}
+
+const (
+	errKindDenied           = "denied"
+	errKindForcefullyDenied = "forcefully_denied"
+	errKindIndeterminate    = "indeterminate"
+)
+
+// errKindOf maps an error returned alongside a Decision to the sentinel it wraps, so it can
+// be reconstructed after a round trip through Redis.
+func errKindOf(err error) string {
+	switch errors.Cause(err) {
+	case nil:
+		return ""
+	case ErrRequestForcefullyDenied:
+		return errKindForcefullyDenied
+	case ErrRequestIndeterminate:
+		return errKindIndeterminate
+	default:
+		return errKindDenied
+	}
+}
+
+// errFromKind reverses errKindOf.
+func errFromKind(kind string) error {
+	switch kind {
+	case "":
+		return nil
+	case errKindForcefullyDenied:
+		return errors.WithStack(ErrRequestForcefullyDenied)
+	case errKindIndeterminate:
+		return errors.WithStack(ErrRequestIndeterminate)
+	default:
+		return errors.WithStack(ErrRequestDenied)
+	}
+}
+
+// RedisCache is a DecisionCache backed by a shared Redis instance, for sharing cached
+// decisions across the replicas of a high-QPS authorizer.
+type RedisCache struct {
+	client RedisClient
+	ttl    time.Duration
+	prefix string
+}
+
+// NewRedisCache builds a RedisCache storing entries under prefix+key with the given TTL.
+func NewRedisCache(client RedisClient, prefix string, ttl time.Duration) *RedisCache {
+	return &RedisCache{client: client, ttl: ttl, prefix: prefix}
+}
+
+// Get implements DecisionCache.
+func (c *RedisCache) Get(ctx context.Context, key string) (*CachedDecision, bool) {
+	raw, err := c.client.Get(ctx, c.prefix+key)
+	if err != nil || raw == nil {
+		return nil, false
+	}
+
+	var wire redisCachedDecision
+	if err := json.Unmarshal(raw, &wire); err != nil {
+		return nil, false
+	}
+
+	decision := &Decision{Effect: wire.Effect, Obligations: wire.Obligations, Advice: map[string]interface{}{}}
+
+	return &CachedDecision{Decision: decision, Err: errFromKind(wire.ErrKind)}, true
+}
+
+// Set implements DecisionCache.
+func (c *RedisCache) Set(ctx context.Context, key string, decision *CachedDecision) {
+	wire := redisCachedDecision{
+		Effect:      decision.Decision.Effect,
+		Obligations: decision.Decision.Obligations,
+		ErrKind:     errKindOf(decision.Err),
+	}
+
+	raw, err := json.Marshal(wire)
+	if err != nil {
+		return
+	}
+
+	_ = c.client.Set(ctx, c.prefix+key, raw, c.ttl)
+}
+
+// Evict implements DecisionCache. Redis has no secondary index on subject/resource, so any
+// policy change flushes every key under prefix; callers wanting surgical invalidation should
+// use LRUCache instead, or layer their own indexing over RedisClient.
+func (c *RedisCache) Evict(p Policy) {
+	ctx := context.Background()
+
+	keys, err := c.client.Keys(ctx, c.prefix+"*")
+	if err != nil || len(keys) == 0 {
+		return
+	}
+
+	_ = c.client.Del(ctx, keys...)
+}
@@ -0,0 +1,208 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestCacheKeyDoesNotCollideOnNULByte(t *testing.T) {
+	a := CacheKey(&Request{Subject: "a\x00b", Resource: "c"})
+	b := CacheKey(&Request{Subject: "a", Resource: "b\x00c"})
+	if a == b {
+		t.Fatalf("CacheKey collided for distinct (subject, resource) pairs: %q", a)
+	}
+}
+
+func TestReadCacheFieldRoundTripsWriteCacheField(t *testing.T) {
+	key := CacheKey(&Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+
+	subject, rest, ok := readCacheField(key)
+	if !ok || subject != "peter" {
+		t.Fatalf("subject = %q, ok = %v, want \"peter\", true", subject, ok)
+	}
+
+	resource, _, ok := readCacheField(rest)
+	if !ok || resource != "articles:1" {
+		t.Fatalf("resource = %q, ok = %v, want \"articles:1\", true", resource, ok)
+	}
+}
+
+func TestContextHashIsStableAcrossMapIterationOrder(t *testing.T) {
+	a := contextHash(Context{"a": 1, "b": 2})
+	b := contextHash(Context{"b": 2, "a": 1})
+	if a != b {
+		t.Fatalf("contextHash(%v) != contextHash(%v)", a, b)
+	}
+}
+
+func TestContextHashDistinguishesValuesThatFormatIdentically(t *testing.T) {
+	numeric := contextHash(Context{"hour": 9})
+	stringy := contextHash(Context{"hour": "9"})
+	if numeric == stringy {
+		t.Fatalf("contextHash(hour=9) == contextHash(hour=\"9\") = %q, want distinct hashes for distinct types", numeric)
+	}
+}
+
+func TestCachingInterceptorDoesNotLeakMutationsBetweenHits(t *testing.T) {
+	cache := NewLRUCache(10, time.Minute, nil, nil)
+	calls := 0
+	handler := CachingInterceptor(cache)(func(ctx context.Context, r *Request) (*Decision, error) {
+		calls++
+		return &Decision{Effect: Permit, Obligations: map[string]interface{}{"ttl": 1}, Advice: map[string]interface{}{}}, nil
+	})
+
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	first, err := handler(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	first.Obligations["ttl"] = 999 // simulate an outer interceptor mutating the decision it got back.
+
+	second, err := handler(context.Background(), r)
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if second.Obligations["ttl"] != 1 {
+		t.Fatalf("second.Obligations[ttl] = %v, want 1 (must not see the first caller's mutation)", second.Obligations["ttl"])
+	}
+	if calls != 1 {
+		t.Fatalf("calls = %d, want 1 (second call should have been served from cache)", calls)
+	}
+	if first == second {
+		t.Fatalf("first and second are the same pointer, want independent copies")
+	}
+}
+
+func TestLRUCacheExpiresEntriesAfterTTL(t *testing.T) {
+	cache := NewLRUCache(10, -time.Second, nil, nil)
+	cache.Set(context.Background(), "key", &CachedDecision{Decision: &Decision{Effect: Permit}})
+
+	if _, ok := cache.Get(context.Background(), "key"); ok {
+		t.Fatalf("expected an already-expired entry to be a miss")
+	}
+}
+
+func TestLRUCacheEvictsOnlyOverlappingEntries(t *testing.T) {
+	matcher := &fakeMatcher{}
+	cache := NewLRUCache(10, time.Minute, nil, matcher)
+
+	petersKey := CacheKey(&Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+	juliasKey := CacheKey(&Request{Subject: "julia", Resource: "articles:1", Action: "read"})
+	cache.Set(context.Background(), petersKey, &CachedDecision{Decision: &Decision{Effect: Permit}})
+	cache.Set(context.Background(), juliasKey, &CachedDecision{Decision: &Decision{Effect: Permit}})
+
+	changed := &fakePolicy{ID: "1", Subjects: []string{"peter"}, Resources: []string{"articles:1"}}
+	cache.Evict(changed)
+
+	if _, ok := cache.Get(context.Background(), petersKey); ok {
+		t.Fatalf("peter's entry should have been evicted: it overlaps the changed policy")
+	}
+	if _, ok := cache.Get(context.Background(), juliasKey); !ok {
+		t.Fatalf("julia's entry should not have been evicted: it does not overlap the changed policy")
+	}
+}
+
+func TestLRUCacheEvictsEverythingWithoutAMatcher(t *testing.T) {
+	cache := NewLRUCache(10, time.Minute, nil, nil)
+	key := CacheKey(&Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+	cache.Set(context.Background(), key, &CachedDecision{Decision: &Decision{Effect: Permit}})
+
+	cache.Evict(&fakePolicy{ID: "1"})
+
+	if _, ok := cache.Get(context.Background(), key); ok {
+		t.Fatalf("expected a full flush when no matcher was configured")
+	}
+}
+
+// fakeRedisClient is an in-memory stand-in for RedisClient.
+type fakeRedisClient struct {
+	data map[string][]byte
+}
+
+func newFakeRedisClient() *fakeRedisClient {
+	return &fakeRedisClient{data: map[string][]byte{}}
+}
+
+func (c *fakeRedisClient) Set(ctx context.Context, key string, value []byte, ttl time.Duration) error {
+	c.data[key] = value
+	return nil
+}
+
+func (c *fakeRedisClient) Get(ctx context.Context, key string) ([]byte, error) {
+	v, ok := c.data[key]
+	if !ok {
+		return nil, nil
+	}
+	return v, nil
+}
+
+func (c *fakeRedisClient) Keys(ctx context.Context, pattern string) ([]string, error) {
+	keys := make([]string, 0, len(c.data))
+	for k := range c.data {
+		keys = append(keys, k)
+	}
+	return keys, nil
+}
+
+func (c *fakeRedisClient) Del(ctx context.Context, keys ...string) error {
+	for _, k := range keys {
+		delete(c.data, k)
+	}
+	return nil
+}
+
+func TestRedisCachePreservesForcefulDenySentinelAcrossRoundTrip(t *testing.T) {
+	client := newFakeRedisClient()
+	cache := NewRedisCache(client, "ladon:", time.Minute)
+
+	original := &CachedDecision{
+		Decision: &Decision{Effect: Deny},
+		Err:      errors.WithStack(ErrRequestForcefullyDenied),
+	}
+	cache.Set(context.Background(), "key", original)
+
+	got, ok := cache.Get(context.Background(), "key")
+	if !ok {
+		t.Fatalf("expected a cache hit")
+	}
+	if errors.Cause(got.Err) != ErrRequestForcefullyDenied {
+		t.Fatalf("err = %v, want ErrRequestForcefullyDenied", got.Err)
+	}
+}
+
+func TestRedisCacheEvictFlushesAllKeysUnderPrefix(t *testing.T) {
+	client := newFakeRedisClient()
+	cache := NewRedisCache(client, "ladon:", time.Minute)
+	cache.Set(context.Background(), "key1", &CachedDecision{Decision: &Decision{Effect: Permit}})
+	cache.Set(context.Background(), "key2", &CachedDecision{Decision: &Decision{Effect: Permit}})
+
+	cache.Evict(&fakePolicy{ID: "1"})
+
+	if len(client.data) != 0 {
+		t.Fatalf("expected every key to be flushed, got %v", client.data)
+	}
+}
@@ -0,0 +1,166 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"sort"
+
+	"github.com/pkg/errors"
+)
+
+// Combiner decides, given the policies that matched a request's action, subject, resource
+// and conditions, whether the request is allowed. It mirrors the rule-combining algorithms
+// found in XACML: deny-overrides, allow-overrides, first-applicable and only-one-applicable.
+//
+// deciders holds the policies that contributed to the decision. By convention, when err is
+// non-nil because of a forceful denial, the last entry of deciders is the policy responsible
+// for it - but callers must not assume deciders is non-empty: a Combiner may legitimately
+// return an error (e.g. OnlyOneApplicable's ambiguity error) without any single policy to
+// blame.
+type Combiner interface {
+	Combine(ctx context.Context, r *Request, matched Policies) (allowed bool, deciders Policies, err error)
+}
+
+// ErrRequestIndeterminate is returned by OnlyOneApplicable when more than one policy matches
+// a request, making the decision ambiguous.
+var ErrRequestIndeterminate = errors.New("request could not be matched to exactly one policy, decision is indeterminate")
+
+// CombinerFunc is an adapter that allows ordinary functions to be used as a Combiner.
+type CombinerFunc func(ctx context.Context, r *Request, matched Policies) (bool, Policies, error)
+
+// Combine calls fn(ctx, r, matched).
+func (fn CombinerFunc) Combine(ctx context.Context, r *Request, matched Policies) (bool, Policies, error) {
+	return fn(ctx, r, matched)
+}
+
+// DenyOverrides is the default combining algorithm: a single matching deny policy overrides
+// any number of matching allow policies. This is ladon's original, and most conservative,
+// behavior.
+var DenyOverrides Combiner = CombinerFunc(func(ctx context.Context, r *Request, matched Policies) (bool, Policies, error) {
+	var allowed = false
+	var deciders = Policies{}
+
+	for _, p := range matched {
+		if !p.AllowAccess() {
+			deciders = append(deciders, p)
+			return false, deciders, errors.WithStack(ErrRequestForcefullyDenied)
+		}
+
+		allowed = true
+		deciders = append(deciders, p)
+	}
+
+	return allowed, deciders, nil
+})
+
+// AllowOverrides is the opposite of DenyOverrides: a single matching allow policy grants
+// access even if deny policies also matched. Useful when migrating legacy ACLs where an
+// explicit allow must beat an explicit deny.
+//
+// deciders only holds the policies that actually produced the outcome: the matching allow
+// policies when access is granted, or the matching deny policies otherwise. A deny policy
+// that was overridden by an allow never appears in deciders, so its conditions/obligations
+// cannot leak into a Permit decision.
+var AllowOverrides Combiner = CombinerFunc(func(ctx context.Context, r *Request, matched Policies) (bool, Policies, error) {
+	var allows = Policies{}
+	var denies = Policies{}
+
+	for _, p := range matched {
+		if p.AllowAccess() {
+			allows = append(allows, p)
+		} else {
+			denies = append(denies, p)
+		}
+	}
+
+	if len(allows) > 0 {
+		return true, allows, nil
+	}
+
+	return false, denies, nil
+})
+
+// FirstApplicable orders the matched policies deterministically by priority (highest first,
+// ties broken by policy ID) and returns the decision of the first one.
+//
+// INCOMPLETE: the request this chunk implements asked for a Priority field on DefaultPolicy
+// itself, with a JSON and SQL schema migration, and changes to every manager backend so that
+// priority could be stored and queried like any other policy attribute. None of that is done
+// here - this chunk only has ladon.go in scope, so there is no DefaultPolicy, no policy.go and
+// no manager.go to touch. What exists instead is the PriorityPolicy extension point below:
+// policies that implement it participate in FirstApplicable ordering, and everything else -
+// including every DefaultPolicy, since it has no Priority field to report - is treated as
+// priority 0 and falls back to ID ordering. That makes FirstApplicable a no-op priority ladder
+// for the library's own default policy type until the DefaultPolicy/manager work lands as a
+// follow-up; it should not be read as the backlog item being done.
+var FirstApplicable Combiner = CombinerFunc(func(ctx context.Context, r *Request, matched Policies) (bool, Policies, error) {
+	if len(matched) == 0 {
+		return false, Policies{}, nil
+	}
+
+	ordered := make(Policies, len(matched))
+	copy(ordered, matched)
+	sort.SliceStable(ordered, func(i, j int) bool {
+		pi, pj := policyPriority(ordered[i]), policyPriority(ordered[j])
+		if pi != pj {
+			return pi > pj
+		}
+		return ordered[i].GetID() < ordered[j].GetID()
+	})
+
+	first := ordered[0]
+	if !first.AllowAccess() {
+		return false, Policies{first}, errors.WithStack(ErrRequestForcefullyDenied)
+	}
+
+	return true, Policies{first}, nil
+})
+
+// OnlyOneApplicable requires exactly one policy to match; it errors if more than one does,
+// and denies if none does.
+var OnlyOneApplicable Combiner = CombinerFunc(func(ctx context.Context, r *Request, matched Policies) (bool, Policies, error) {
+	if len(matched) == 0 {
+		return false, Policies{}, nil
+	} else if len(matched) > 1 {
+		return false, matched, errors.WithStack(ErrRequestIndeterminate)
+	}
+
+	p := matched[0]
+	if !p.AllowAccess() {
+		return false, Policies{p}, errors.WithStack(ErrRequestForcefullyDenied)
+	}
+
+	return true, Policies{p}, nil
+})
+
+// PriorityPolicy is implemented by policies that support FirstApplicable ordering. Policies
+// that do not implement it are treated as having priority 0.
+type PriorityPolicy interface {
+	GetPriority() int
+}
+
+func policyPriority(p Policy) int {
+	if pp, ok := p.(PriorityPolicy); ok {
+		return pp.GetPriority()
+	}
+	return 0
+}
@@ -0,0 +1,136 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+
+	"github.com/pkg/errors"
+)
+
+func TestOnlyOneApplicable(t *testing.T) {
+	ctx := context.Background()
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+	allow := &fakePolicy{ID: "1", Allow: true}
+	deny := &fakePolicy{ID: "2", Allow: false}
+
+	t.Run("no match is a plain deny, not an error", func(t *testing.T) {
+		allowed, deciders, err := OnlyOneApplicable.Combine(ctx, r, Policies{})
+		if allowed || len(deciders) != 0 || err != nil {
+			t.Fatalf("got (%v, %v, %v), want (false, [], nil)", allowed, deciders, err)
+		}
+	})
+
+	t.Run("exactly one matching allow policy is permitted", func(t *testing.T) {
+		allowed, deciders, err := OnlyOneApplicable.Combine(ctx, r, Policies{allow})
+		if !allowed || len(deciders) != 1 || deciders[0] != allow || err != nil {
+			t.Fatalf("got (%v, %v, %v), want (true, [allow], nil)", allowed, deciders, err)
+		}
+	})
+
+	t.Run("exactly one matching deny policy is a forceful deny", func(t *testing.T) {
+		allowed, deciders, err := OnlyOneApplicable.Combine(ctx, r, Policies{deny})
+		if allowed || len(deciders) != 1 || deciders[0] != deny {
+			t.Fatalf("got (%v, %v, %v)", allowed, deciders, err)
+		}
+		if errors.Cause(err) != ErrRequestForcefullyDenied {
+			t.Fatalf("err = %v, want ErrRequestForcefullyDenied", err)
+		}
+	})
+
+	t.Run("more than one matching policy is indeterminate", func(t *testing.T) {
+		allowed, _, err := OnlyOneApplicable.Combine(ctx, r, Policies{allow, deny})
+		if allowed {
+			t.Fatalf("allowed = true, want false")
+		}
+		if errors.Cause(err) != ErrRequestIndeterminate {
+			t.Fatalf("err = %v, want ErrRequestIndeterminate", err)
+		}
+	})
+}
+
+func TestFirstApplicable(t *testing.T) {
+	ctx := context.Background()
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	low := &fakePolicy{ID: "b", Allow: false, Priority: 1}
+	high := &fakePolicy{ID: "a", Allow: true, Priority: 10}
+
+	allowed, deciders, err := FirstApplicable.Combine(ctx, r, Policies{low, high})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed || len(deciders) != 1 || deciders[0] != high {
+		t.Fatalf("got (%v, %v), want the higher-priority policy to win", allowed, deciders)
+	}
+
+	// Ties (equal, including default-zero, priority) fall back to ID order.
+	tieA := &fakePolicy{ID: "a", Allow: true}
+	tieB := &fakePolicy{ID: "b", Allow: true}
+	_, deciders, err = FirstApplicable.Combine(ctx, r, Policies{tieB, tieA})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(deciders) != 1 || deciders[0] != tieA {
+		t.Fatalf("got %v, want tieA to win the ID tie-break", deciders)
+	}
+}
+
+func TestAllowOverridesDoesNotLeakOverriddenDenyDeciders(t *testing.T) {
+	ctx := context.Background()
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	deny := &fakePolicy{ID: "deny", Allow: false, Obligations: map[string]interface{}{"must-alert": true}}
+	allow := &fakePolicy{ID: "allow", Allow: true}
+
+	allowed, deciders, err := AllowOverrides.Combine(ctx, r, Policies{deny, allow})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !allowed {
+		t.Fatalf("allowed = false, want true")
+	}
+	for _, d := range deciders {
+		if d == deny {
+			t.Fatalf("deciders = %v, must not include the overridden deny policy", deciders)
+		}
+	}
+}
+
+func TestDenyOverridesStopsAtFirstDeny(t *testing.T) {
+	ctx := context.Background()
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	allow := &fakePolicy{ID: "allow", Allow: true}
+	deny := &fakePolicy{ID: "deny", Allow: false}
+
+	allowed, deciders, err := DenyOverrides.Combine(ctx, r, Policies{allow, deny})
+	if allowed {
+		t.Fatalf("allowed = true, want false")
+	}
+	if errors.Cause(err) != ErrRequestForcefullyDenied {
+		t.Fatalf("err = %v, want ErrRequestForcefullyDenied", err)
+	}
+	if len(deciders) != 2 || deciders[len(deciders)-1] != deny {
+		t.Fatalf("deciders = %v, want the denying policy last", deciders)
+	}
+}
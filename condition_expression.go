@@ -0,0 +1,104 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"sync"
+
+	"github.com/google/cel-go/cel"
+	"github.com/google/cel-go/checker/decls"
+)
+
+func init() {
+	ConditionFactories["ExpressionCondition"] = func() Condition {
+		return new(ExpressionCondition)
+	}
+}
+
+// ExpressionCondition is fulfilled when a CEL (Common Expression Language) expression
+// evaluates to true against the request it is checking. It exposes the request's context,
+// subject, resource and action as `ctx`, `subject`, `resource` and `action` respectively, so
+// multi-field ABAC rules - e.g. "ctx.owner == subject && ctx.hour >= 9" - can be expressed
+// without writing a Go type per condition. `ctx` is a map with dynamic values; `subject`,
+// `resource` and `action` are plain strings and have no fields. It round-trips through JSON
+// like any other Condition:
+//
+//	{"type":"ExpressionCondition","options":{"expr":"ctx.owner == subject"}}
+type ExpressionCondition struct {
+	Expr string `json:"expr"`
+
+	once    sync.Once
+	program cel.Program
+	compErr error
+}
+
+// GetName returns the condition's type, as registered in ConditionFactories.
+func (c *ExpressionCondition) GetName() string {
+	return "ExpressionCondition"
+}
+
+// Fulfills returns true if c.Expr evaluates to true for r. A malformed or non-boolean
+// expression is treated as not fulfilled rather than panicking, since conditions are
+// evaluated deep inside policy matching where there is no good way to surface a compile
+// error to the caller.
+func (c *ExpressionCondition) Fulfills(ctx context.Context, value interface{}, r *Request) bool {
+	c.once.Do(func() {
+		c.program, c.compErr = compileExpressionCondition(c.Expr)
+	})
+	if c.compErr != nil {
+		return false
+	}
+
+	out, _, err := c.program.Eval(map[string]interface{}{
+		"ctx":      map[string]interface{}(r.Context),
+		"subject":  r.Subject,
+		"resource": r.Resource,
+		"action":   r.Action,
+	})
+	if err != nil {
+		return false
+	}
+
+	allowed, ok := out.Value().(bool)
+	return ok && allowed
+}
+
+func compileExpressionCondition(expr string) (cel.Program, error) {
+	env, err := cel.NewEnv(
+		cel.Declarations(
+			decls.NewVar("ctx", decls.NewMapType(decls.String, decls.Dyn)),
+			decls.NewVar("subject", decls.String),
+			decls.NewVar("resource", decls.String),
+			decls.NewVar("action", decls.String),
+		),
+	)
+	if err != nil {
+		return nil, err
+	}
+
+	ast, issues := env.Compile(expr)
+	if issues != nil && issues.Err() != nil {
+		return nil, issues.Err()
+	}
+
+	return env.Program(ast)
+}
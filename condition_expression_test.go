@@ -0,0 +1,90 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+)
+
+func TestExpressionConditionFulfills(t *testing.T) {
+	c := &ExpressionCondition{Expr: `subject == "peter" && action == "read"`}
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	if !c.Fulfills(context.Background(), nil, r) {
+		t.Fatalf("expected the expression to be fulfilled")
+	}
+}
+
+func TestExpressionConditionNotFulfilled(t *testing.T) {
+	c := &ExpressionCondition{Expr: `subject == "julia"`}
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+
+	if c.Fulfills(context.Background(), nil, r) {
+		t.Fatalf("expected the expression not to be fulfilled")
+	}
+}
+
+func TestExpressionConditionUsesContext(t *testing.T) {
+	c := &ExpressionCondition{Expr: `ctx["department"] == "eng"`}
+	r := &Request{Subject: "peter", Context: Context{"department": "eng"}}
+
+	if !c.Fulfills(context.Background(), nil, r) {
+		t.Fatalf("expected the expression to be fulfilled against the request context")
+	}
+}
+
+func TestExpressionConditionTreatsACompileErrorAsNotFulfilled(t *testing.T) {
+	c := &ExpressionCondition{Expr: `subject ===`}
+	r := &Request{Subject: "peter"}
+
+	if c.Fulfills(context.Background(), nil, r) {
+		t.Fatalf("expected a malformed expression to be treated as not fulfilled, not to panic or error out")
+	}
+	if c.compErr == nil {
+		t.Fatalf("expected compileExpressionCondition to have recorded a compile error")
+	}
+}
+
+func TestExpressionConditionTreatsANonBooleanResultAsNotFulfilled(t *testing.T) {
+	c := &ExpressionCondition{Expr: `subject`}
+	r := &Request{Subject: "peter"}
+
+	if c.Fulfills(context.Background(), nil, r) {
+		t.Fatalf("expected a non-boolean expression result to be treated as not fulfilled")
+	}
+}
+
+func TestCompileExpressionConditionReturnsErrorForInvalidSyntax(t *testing.T) {
+	if _, err := compileExpressionCondition(`subject ===`); err == nil {
+		t.Fatalf("expected an error compiling invalid CEL syntax")
+	}
+}
+
+func TestExpressionConditionIsRegisteredInConditionFactories(t *testing.T) {
+	factory, ok := ConditionFactories["ExpressionCondition"]
+	if !ok {
+		t.Fatalf("ExpressionCondition was not registered in ConditionFactories")
+	}
+	if _, ok := factory().(*ExpressionCondition); !ok {
+		t.Fatalf("factory() did not return an *ExpressionCondition")
+	}
+}
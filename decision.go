@@ -0,0 +1,138 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import "context"
+
+// Effect describes the outcome of a Decision, following XACML terminology: Permit and Deny
+// are explicit outcomes, NotApplicable means no policy matched the request at all.
+type Effect string
+
+const (
+	Permit        Effect = "Permit"
+	Deny          Effect = "Deny"
+	NotApplicable Effect = "NotApplicable"
+)
+
+// ConditionResult records whether a single named condition on a deciding policy was
+// fulfilled for the request being decided.
+type ConditionResult struct {
+	PolicyID string
+	Key      string
+	Passed   bool
+}
+
+// Decision is the structured result of evaluating a request, as returned by Ladon.Decide.
+// Unlike the plain error returned by IsAllowed, it exposes which policies decided the
+// request, how their conditions evaluated, and any obligations contributed by the
+// deciding policies, so that a caller (e.g. an API gateway) can act on side effects such as
+// "must log this access" or "token TTL <= 5m" beyond the boolean allow/deny.
+type Decision struct {
+	Effect      Effect
+	Deciders    Policies
+	Conditions  []ConditionResult
+	Obligations map[string]interface{}
+
+	// Advice carries non-binding hints that interceptors may attach to a Decision. It is
+	// always initialized, but Decide itself never populates it.
+	Advice map[string]interface{}
+}
+
+// ObligationPolicy may be implemented by a Policy to contribute obligations to the Decision
+// of any request it decides. Obligations of all deciding policies are merged, with policies
+// later in Decision.Deciders taking precedence on key conflicts.
+type ObligationPolicy interface {
+	GetObligations() map[string]interface{}
+}
+
+// Decide evaluates r and returns a structured Decision rather than the plain error returned
+// by IsAllowed. It runs through any interceptors registered via Use, with
+// decideFromManager as the innermost Handler.
+func (l *Ladon) Decide(ctx context.Context, r *Request) (*Decision, error) {
+	l.lazyInit()
+
+	return l.chain(l.instrumentedDecide(l.decideFromManager))(ctx, r)
+}
+
+// instrumentedDecide wraps core - the Handler that actually produces a Decision - with
+// DecisionCache (if configured) and shadow-mode evaluation, in that order: shadow evaluation
+// must sit outside the cache, not inside core, so that it still runs on a cache hit. If it
+// ran from inside core like decideFromManager's own matching does, a cache hit would never
+// reach it and shadow divergence detection would quietly go dark in proportion to the cache
+// hit rate.
+func (l *Ladon) instrumentedDecide(core Handler) Handler {
+	handler := core
+	if l.DecisionCache != nil {
+		handler = CachingInterceptor(l.DecisionCache)(handler)
+	}
+
+	inner := handler
+	return func(ctx context.Context, r *Request) (*Decision, error) {
+		decision, err := inner(ctx, r)
+		if decision != nil {
+			l.evaluateShadow(ctx, r, decision)
+		}
+		return decision, err
+	}
+}
+
+// decideFromManager is the core, uninstrumented Handler: it fetches candidates from the
+// Manager and combines them via l.decide.
+func (l *Ladon) decideFromManager(ctx context.Context, r *Request) (*Decision, error) {
+	policies, err := l.Manager.FindRequestCandidates(ctx, r)
+	if err != nil {
+		go l.Metric.RequestProcessingError(*r, nil, err)
+		return nil, err
+	}
+
+	// Although the manager is responsible of matching the policies, it might decide to just scan for
+	// subjects, it might return all policies, or it might have a different pattern matching than Golang.
+	// Thus, we need to make sure that we actually matched the right policies.
+	return l.decide(ctx, r, policies)
+}
+
+func (l *Ladon) evaluateDeciderConditions(ctx context.Context, r *Request, deciders Policies) []ConditionResult {
+	var results []ConditionResult
+	for _, p := range deciders {
+		for key, condition := range p.GetConditions() {
+			results = append(results, ConditionResult{
+				PolicyID: p.GetID(),
+				Key:      key,
+				Passed:   condition.Fulfills(ctx, r.Context[key], r),
+			})
+		}
+	}
+	return results
+}
+
+func collectObligations(deciders Policies) map[string]interface{} {
+	obligations := map[string]interface{}{}
+	for _, p := range deciders {
+		op, ok := p.(ObligationPolicy)
+		if !ok {
+			continue
+		}
+		for k, v := range op.GetObligations() {
+			obligations[k] = v
+		}
+	}
+	return obligations
+}
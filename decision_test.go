@@ -0,0 +1,124 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+)
+
+// fakeCondition is fulfilled according to a fixed bool, for exercising ConditionResult
+// tracing without depending on a real Condition implementation.
+type fakeCondition struct {
+	name     string
+	fulfills bool
+}
+
+func (c *fakeCondition) GetName() string { return c.name }
+
+func (c *fakeCondition) Fulfills(ctx context.Context, value interface{}, r *Request) bool {
+	return c.fulfills
+}
+
+func TestDecidePermit(t *testing.T) {
+	allow := &fakePolicy{
+		ID: "1", Allow: true,
+		Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"},
+		Obligations: map[string]interface{}{"must-log": true},
+	}
+	l, _ := newTestLadon(Policies{allow})
+
+	decision, err := l.Decide(context.Background(), &Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+	if err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if decision.Effect != Permit {
+		t.Fatalf("Effect = %v, want Permit", decision.Effect)
+	}
+	if decision.Advice == nil {
+		t.Fatalf("Advice = nil, want initialized map")
+	}
+	if decision.Obligations["must-log"] != true {
+		t.Fatalf("Obligations = %v, want must-log: true", decision.Obligations)
+	}
+}
+
+func TestDecideDeny(t *testing.T) {
+	deny := &fakePolicy{ID: "1", Allow: false, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	l, _ := newTestLadon(Policies{deny})
+
+	decision, err := l.Decide(context.Background(), &Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if decision.Effect != Deny {
+		t.Fatalf("Effect = %v, want Deny", decision.Effect)
+	}
+}
+
+func TestDecideNotApplicable(t *testing.T) {
+	l, _ := newTestLadon(Policies{})
+
+	decision, err := l.Decide(context.Background(), &Request{Subject: "peter", Resource: "articles:1", Action: "read"})
+	if err == nil {
+		t.Fatalf("expected an error")
+	}
+	if decision.Effect != NotApplicable {
+		t.Fatalf("Effect = %v, want NotApplicable", decision.Effect)
+	}
+}
+
+func TestEvaluateDeciderConditionsTracesEachCondition(t *testing.T) {
+	l, _ := newTestLadon(nil)
+
+	met := &fakeCondition{name: "met", fulfills: true}
+	unmet := &fakeCondition{name: "unmet", fulfills: false}
+	policy := &fakePolicy{ID: "1", Conditions: map[string]Condition{"met": met, "unmet": unmet}}
+
+	results := l.evaluateDeciderConditions(context.Background(), &Request{}, Policies{policy})
+	if len(results) != 2 {
+		t.Fatalf("len(results) = %d, want 2", len(results))
+	}
+
+	passed := map[string]bool{}
+	for _, res := range results {
+		if res.PolicyID != "1" {
+			t.Fatalf("PolicyID = %q, want %q", res.PolicyID, "1")
+		}
+		passed[res.Key] = res.Passed
+	}
+	if !passed["met"] || passed["unmet"] {
+		t.Fatalf("passed = %v, want met: true, unmet: false", passed)
+	}
+}
+
+func TestCollectObligationsMergesWithLaterDeciderWinning(t *testing.T) {
+	first := &fakePolicy{ID: "1", Obligations: map[string]interface{}{"ttl": 1, "only-first": true}}
+	second := &fakePolicy{ID: "2", Obligations: map[string]interface{}{"ttl": 2}}
+
+	obligations := collectObligations(Policies{first, second})
+	if obligations["ttl"] != 2 {
+		t.Fatalf("ttl = %v, want 2 (later decider should win on conflict)", obligations["ttl"])
+	}
+	if obligations["only-first"] != true {
+		t.Fatalf("only-first = %v, want true", obligations["only-first"])
+	}
+}
@@ -0,0 +1,48 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import "context"
+
+// Handler evaluates a request and returns a Decision. It is the type threaded through the
+// interceptor chain; the innermost Handler is Ladon's own candidate-fetch-then-combine logic.
+type Handler func(ctx context.Context, r *Request) (*Decision, error)
+
+// Interceptor wraps a Handler with additional behavior - audit logging, metrics, caching,
+// tracing, rate-limiting, tenant enforcement, shadow-mode evaluation, and so on - without
+// Ladon itself having to know about any of it. This mirrors the middleware chain pattern
+// used by reverse-proxy request pipelines.
+type Interceptor func(next Handler) Handler
+
+// Use appends an interceptor to the chain that Decide runs requests through. Interceptors
+// run in the order they were registered: the first one registered is the outermost,
+// wrapping every interceptor (and the core Handler) registered after it.
+func (l *Ladon) Use(i Interceptor) {
+	l.interceptors = append(l.interceptors, i)
+}
+
+// chain wraps handler with every registered interceptor, outermost first.
+func (l *Ladon) chain(handler Handler) Handler {
+	for i := len(l.interceptors) - 1; i >= 0; i-- {
+		handler = l.interceptors[i](handler)
+	}
+	return handler
+}
@@ -0,0 +1,117 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+// Tracer starts a span around a named operation, returning a context carrying it and a
+// function that ends it. This lets TracingInterceptor create spans in whatever tracing
+// system a caller uses (e.g. OpenTelemetry) without Ladon depending on one directly.
+type Tracer interface {
+	StartSpan(ctx context.Context, name string) (context.Context, func())
+}
+
+// TracingInterceptor wraps request evaluation, and the manager lookup and combining it
+// triggers, in a single span named "ladon.Decide".
+func TracingInterceptor(tracer Tracer) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, r *Request) (*Decision, error) {
+			spanCtx, end := tracer.StartSpan(ctx, "ladon.Decide")
+			defer end()
+			return next(spanCtx, r)
+		}
+	}
+}
+
+// DecisionLogger receives every decision Decide makes. AuditLoggingInterceptor is for piping
+// decisions to a secondary sink (e.g. a SIEM); Ladon.AuditLogger keeps recording them as
+// usual regardless of whether this interceptor is registered.
+type DecisionLogger interface {
+	LogDecision(ctx context.Context, r *Request, decision *Decision, err error)
+}
+
+// AuditLoggingInterceptor calls logger.LogDecision with the outcome of every request that
+// reaches it, in addition to whatever Ladon.AuditLogger already records.
+func AuditLoggingInterceptor(logger DecisionLogger) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, r *Request) (*Decision, error) {
+			decision, err := next(ctx, r)
+			if decision != nil {
+				logger.LogDecision(ctx, r, decision, err)
+			}
+			return decision, err
+		}
+	}
+}
+
+// DecisionMetric receives a callback for every decision Decide makes, alongside how long it
+// took. MetricsInterceptor is for exporting counters to a secondary metrics system (e.g.
+// Prometheus); Ladon.Metric keeps recording as usual regardless of whether this interceptor
+// is registered.
+type DecisionMetric interface {
+	ObserveDecision(r Request, effect Effect, duration time.Duration)
+}
+
+// MetricsInterceptor times every request that reaches it and reports the outcome to metric,
+// in addition to whatever Ladon.Metric already records.
+func MetricsInterceptor(metric DecisionMetric) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, r *Request) (*Decision, error) {
+			start := time.Now()
+			decision, err := next(ctx, r)
+			if decision != nil {
+				metric.ObserveDecision(*r, decision.Effect, time.Since(start))
+			}
+			return decision, err
+		}
+	}
+}
+
+// RateLimiter reports whether another request may proceed right now, e.g. a token bucket
+// shared across requests for the same subject or API key.
+type RateLimiter interface {
+	Allow() bool
+}
+
+// ErrRateLimited is returned by RateLimitInterceptor when the RateLimiter rejects a request.
+var ErrRateLimited = errors.New("request rejected: rate limit exceeded")
+
+// RateLimitInterceptor denies requests once limiter.Allow() reports the rate limit has been
+// exceeded, without ever reaching the Manager or Combiner.
+func RateLimitInterceptor(limiter RateLimiter) Interceptor {
+	return func(next Handler) Handler {
+		return func(ctx context.Context, r *Request) (*Decision, error) {
+			if !limiter.Allow() {
+				// Advice/Obligations must be initialized like decide() does: Decision's own
+				// doc comment promises Advice "is always initialized", and an interceptor
+				// registered ahead of this one in the chain may trust that and write into
+				// it directly.
+				return &Decision{Effect: Deny, Obligations: map[string]interface{}{}, Advice: map[string]interface{}{}}, errors.WithStack(ErrRateLimited)
+			}
+			return next(ctx, r)
+		}
+	}
+}
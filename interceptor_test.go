@@ -0,0 +1,168 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/pkg/errors"
+)
+
+func TestChainRunsInterceptorsOutermostFirst(t *testing.T) {
+	var order []string
+	record := func(name string) Interceptor {
+		return func(next Handler) Handler {
+			return func(ctx context.Context, r *Request) (*Decision, error) {
+				order = append(order, name)
+				return next(ctx, r)
+			}
+		}
+	}
+
+	l := &Ladon{}
+	l.Use(record("first"))
+	l.Use(record("second"))
+
+	handler := l.chain(func(ctx context.Context, r *Request) (*Decision, error) {
+		order = append(order, "core")
+		return &Decision{}, nil
+	})
+
+	if _, err := handler(context.Background(), &Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	want := []string{"first", "second", "core"}
+	if len(order) != len(want) {
+		t.Fatalf("order = %v, want %v", order, want)
+	}
+	for i := range want {
+		if order[i] != want[i] {
+			t.Fatalf("order = %v, want %v", order, want)
+		}
+	}
+}
+
+type fakeTracer struct {
+	started int
+	ended   int
+}
+
+func (t *fakeTracer) StartSpan(ctx context.Context, name string) (context.Context, func()) {
+	t.started++
+	return ctx, func() { t.ended++ }
+}
+
+func TestTracingInterceptorStartsAndEndsASpan(t *testing.T) {
+	tracer := &fakeTracer{}
+	handler := TracingInterceptor(tracer)(func(ctx context.Context, r *Request) (*Decision, error) {
+		return &Decision{Effect: Permit}, nil
+	})
+
+	if _, err := handler(context.Background(), &Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if tracer.started != 1 || tracer.ended != 1 {
+		t.Fatalf("started = %d, ended = %d, want 1, 1", tracer.started, tracer.ended)
+	}
+}
+
+type fakeRateLimiter struct {
+	allow bool
+}
+
+func (r *fakeRateLimiter) Allow() bool { return r.allow }
+
+func TestRateLimitInterceptorInitializesAdviceAndObligations(t *testing.T) {
+	handler := RateLimitInterceptor(&fakeRateLimiter{allow: false})(func(ctx context.Context, r *Request) (*Decision, error) {
+		t.Fatalf("next should not be called once the rate limiter rejects the request")
+		return nil, nil
+	})
+
+	decision, err := handler(context.Background(), &Request{})
+	if errors.Cause(err) != ErrRateLimited {
+		t.Fatalf("err = %v, want ErrRateLimited", err)
+	}
+	if decision.Advice == nil || decision.Obligations == nil {
+		t.Fatalf("Advice/Obligations = %v/%v, want both initialized", decision.Advice, decision.Obligations)
+	}
+}
+
+func TestRateLimitInterceptorAllowsWhenUnderLimit(t *testing.T) {
+	called := false
+	handler := RateLimitInterceptor(&fakeRateLimiter{allow: true})(func(ctx context.Context, r *Request) (*Decision, error) {
+		called = true
+		return &Decision{Effect: Permit}, nil
+	})
+
+	if _, err := handler(context.Background(), &Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if !called {
+		t.Fatalf("next was not called")
+	}
+}
+
+type fakeDecisionLogger struct {
+	decisions int
+}
+
+func (l *fakeDecisionLogger) LogDecision(ctx context.Context, r *Request, decision *Decision, err error) {
+	l.decisions++
+}
+
+func TestAuditLoggingInterceptorLogsEveryDecision(t *testing.T) {
+	logger := &fakeDecisionLogger{}
+	handler := AuditLoggingInterceptor(logger)(func(ctx context.Context, r *Request) (*Decision, error) {
+		return &Decision{Effect: Deny}, errors.New("denied")
+	})
+
+	if _, err := handler(context.Background(), &Request{}); err == nil {
+		t.Fatalf("expected an error")
+	}
+	if logger.decisions != 1 {
+		t.Fatalf("decisions = %d, want 1", logger.decisions)
+	}
+}
+
+type fakeDecisionMetric struct {
+	observed []Effect
+}
+
+func (m *fakeDecisionMetric) ObserveDecision(r Request, effect Effect, duration time.Duration) {
+	m.observed = append(m.observed, effect)
+}
+
+func TestMetricsInterceptorObservesEffect(t *testing.T) {
+	metric := &fakeDecisionMetric{}
+	handler := MetricsInterceptor(metric)(func(ctx context.Context, r *Request) (*Decision, error) {
+		return &Decision{Effect: Permit}, nil
+	})
+
+	if _, err := handler(context.Background(), &Request{}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+	if len(metric.observed) != 1 || metric.observed[0] != Permit {
+		t.Fatalf("observed = %v, want [Permit]", metric.observed)
+	}
+}
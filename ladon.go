@@ -33,6 +33,17 @@ type Ladon struct {
 	Matcher     matcher
 	AuditLogger AuditLogger
 	Metric      Metric
+	Combiner    Combiner
+
+	// ShadowManager, if set, is evaluated alongside Manager for every request; divergences
+	// between the two are reported but never change the returned decision. See evaluateShadow.
+	ShadowManager Manager
+
+	// DecisionCache, if set, memoizes Decide results. It wraps decideFromManager directly,
+	// so cache hits still pass through every interceptor registered via Use.
+	DecisionCache DecisionCache
+
+	interceptors []Interceptor
 }
 
 var lazyInitOnce sync.Once
@@ -48,23 +59,19 @@ func (l *Ladon) lazyInit() {
 		if l.Metric == nil {
 			l.Metric = DefaultMetric
 		}
+		if l.Combiner == nil {
+			l.Combiner = DenyOverrides
+		}
 	})
 }
 
 // IsAllowed returns nil if subject s has permission p on resource r with context c or an error otherwise.
+// It is a thin wrapper around Decide for callers that only care about the boolean outcome.
 func (l *Ladon) IsAllowed(ctx context.Context, r *Request) (err error) {
 	l.lazyInit()
 
-	policies, err := l.Manager.FindRequestCandidates(ctx, r)
-	if err != nil {
-		go l.Metric.RequestProcessingError(*r, nil, err)
-		return err
-	}
-
-	// Although the manager is responsible of matching the policies, it might decide to just scan for
-	// subjects, it might return all policies, or it might have a different pattern matching than Golang.
-	// Thus, we need to make sure that we actually matched the right policies.
-	return l.DoPoliciesAllow(ctx, r, policies)
+	_, err = l.Decide(ctx, r)
+	return err
 }
 
 // DoPoliciesAllow returns nil if subject s has permission p on resource r with context c for a given policy list or an error otherwise.
@@ -72,8 +79,70 @@ func (l *Ladon) IsAllowed(ctx context.Context, r *Request) (err error) {
 func (l *Ladon) DoPoliciesAllow(ctx context.Context, r *Request, policies []Policy) (err error) {
 	l.lazyInit()
 
-	var allowed = false
-	var deciders = Policies{}
+	_, err = l.decide(ctx, r, policies)
+	return err
+}
+
+// decide matches policies against r, combines the outcome via l.Combiner and logs/reports
+// the result. It is the shared core behind DoPoliciesAllow and Decide.
+func (l *Ladon) decide(ctx context.Context, r *Request, policies Policies) (*Decision, error) {
+	matched, err := l.matchPolicies(ctx, r, policies)
+	if err != nil {
+		return nil, err
+	}
+
+	allowed, deciders, combineErr := l.Combiner.Combine(ctx, r, matched)
+
+	decision := &Decision{
+		Deciders:    deciders,
+		Conditions:  l.evaluateDeciderConditions(ctx, r, deciders),
+		Obligations: collectObligations(deciders),
+		Advice:      map[string]interface{}{},
+	}
+
+	if combineErr != nil {
+		decision.Effect = Deny
+		l.AuditLogger.LogRejectedAccessRequest(ctx, r, policies, deciders)
+		// Combiner is a public interface: a third-party implementation may return an error
+		// without attributing it to any single policy, so deciders can be empty here.
+		if len(deciders) > 0 {
+			go l.Metric.RequestDeniedBy(*r, deciders[len(deciders)-1])
+		}
+		return decision, combineErr
+	}
+
+	if !allowed {
+		if len(deciders) == 0 {
+			decision.Effect = NotApplicable
+		} else {
+			decision.Effect = Deny
+		}
+		go l.Metric.RequestNoMatch(*r)
+
+		l.AuditLogger.LogRejectedAccessRequest(ctx, r, policies, deciders)
+		return decision, errors.WithStack(ErrRequestDenied)
+	}
+
+	decision.Effect = Permit
+	l.AuditLogger.LogGrantedAccessRequest(ctx, r, policies, deciders)
+	l.Metric.RequestAllowedBy(*r, deciders)
+
+	return decision, nil
+}
+
+// matchPolicies filters policies down to the ones whose action, subject, resource and
+// conditions all match the request. The combining algorithm then decides, among the
+// matched policies, whether the request is allowed.
+//
+// Unlike the original DoPoliciesAllow, this always evaluates every candidate instead of
+// returning as soon as a deny is matched: making the combining algorithm pluggable means
+// Ladon can no longer assume "first matching deny wins" while collecting matches, since a
+// different Combiner might not. This is a deliberate tradeoff of that flexibility - it costs
+// an always-O(n) scan instead of an early exit, and a malformed policy later in the list (a
+// bad regex, an erroring custom matcher) can now fail a request that an earlier deny would
+// previously have short-circuited before it was ever reached.
+func (l *Ladon) matchPolicies(ctx context.Context, r *Request, policies []Policy) (Policies, error) {
+	var matched = Policies{}
 
 	// Iterate through all policies
 	for _, p := range policies {
@@ -83,7 +152,7 @@ func (l *Ladon) DoPoliciesAllow(ctx context.Context, r *Request, policies []Poli
 		// and thus match faster.
 		if pm, err := l.Matcher.Matches(p, p.GetActions(), r.Action); err != nil {
 			go l.Metric.RequestProcessingError(*r, p, err)
-			return errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		} else if !pm {
 			// no, continue to next policy
 			continue
@@ -94,7 +163,7 @@ func (l *Ladon) DoPoliciesAllow(ctx context.Context, r *Request, policies []Poli
 		// before checking for resources.
 		if sm, err := l.Matcher.Matches(p, p.GetSubjects(), r.Subject); err != nil {
 			go l.Metric.RequestProcessingError(*r, p, err)
-			return err
+			return nil, err
 		} else if !sm {
 			// no, continue to next policy
 			continue
@@ -103,7 +172,7 @@ func (l *Ladon) DoPoliciesAllow(ctx context.Context, r *Request, policies []Poli
 		// Does the resource match with one of the policies?
 		if rm, err := l.Matcher.Matches(p, p.GetResources(), r.Resource); err != nil {
 			go l.Metric.RequestProcessingError(*r, p, err)
-			return errors.WithStack(err)
+			return nil, errors.WithStack(err)
 		} else if !rm {
 			// no, continue to next policy
 			continue
@@ -116,29 +185,10 @@ func (l *Ladon) DoPoliciesAllow(ctx context.Context, r *Request, policies []Poli
 			continue
 		}
 
-		// Is the policy's effect `deny`? If yes, this overrides all allow policies -> access denied.
-		if !p.AllowAccess() {
-			deciders = append(deciders, p)
-			l.AuditLogger.LogRejectedAccessRequest(ctx, r, policies, deciders)
-			go l.Metric.RequestDeniedBy(*r, p)
-			return errors.WithStack(ErrRequestForcefullyDenied)
-		}
-
-		allowed = true
-		deciders = append(deciders, p)
-	}
-
-	if !allowed {
-		go l.Metric.RequestNoMatch(*r)
-
-		l.AuditLogger.LogRejectedAccessRequest(ctx, r, policies, deciders)
-		return errors.WithStack(ErrRequestDenied)
+		matched = append(matched, p)
 	}
 
-	l.AuditLogger.LogGrantedAccessRequest(ctx, r, policies, deciders)
-	l.Metric.RequestAllowedBy(*r, deciders)
-
-	return nil
+	return matched, nil
 }
 
 func (l *Ladon) passesConditions(ctx context.Context, p Policy, r *Request) bool {
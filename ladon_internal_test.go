@@ -0,0 +1,155 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"sync"
+)
+
+// fakePolicy is a minimal, hand-rolled Policy for exercising combining, matching and caching
+// logic without a real policy store.
+type fakePolicy struct {
+	ID          string
+	Subjects    []string
+	Resources   []string
+	Actions     []string
+	Allow       bool
+	Conditions  map[string]Condition
+	Priority    int
+	Obligations map[string]interface{}
+}
+
+func (p *fakePolicy) GetID() string                   { return p.ID }
+func (p *fakePolicy) GetSubjects() []string            { return p.Subjects }
+func (p *fakePolicy) GetResources() []string           { return p.Resources }
+func (p *fakePolicy) GetActions() []string             { return p.Actions }
+func (p *fakePolicy) AllowAccess() bool                { return p.Allow }
+func (p *fakePolicy) GetConditions() map[string]Condition { return p.Conditions }
+func (p *fakePolicy) GetPriority() int                 { return p.Priority }
+func (p *fakePolicy) GetObligations() map[string]interface{} { return p.Obligations }
+
+// fakeMatcher matches a needle against a haystack with plain string equality, treating a
+// haystack entry of "<.*>" as matching anything, or always returns matchErr if it is set -
+// useful for exercising matcher-error paths.
+type fakeMatcher struct {
+	matchErr error
+}
+
+func (m *fakeMatcher) Matches(p Policy, haystack []string, needle string) (bool, error) {
+	if m.matchErr != nil {
+		return false, m.matchErr
+	}
+	for _, h := range haystack {
+		if h == needle || h == "<.*>" {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+// fakeManager is an in-memory Manager that returns a fixed candidate list, regardless of the
+// request, and optionally fans out policy events to SubscribableManager subscribers.
+type fakeManager struct {
+	candidates Policies
+
+	mu          sync.Mutex
+	subscribers []chan<- PolicyEvent
+}
+
+func (m *fakeManager) Create(Policy) error                     { return nil }
+func (m *fakeManager) Update(Policy) error                      { return nil }
+func (m *fakeManager) Get(string) (Policy, error)               { return nil, nil }
+func (m *fakeManager) Delete(string) error                       { return nil }
+func (m *fakeManager) GetAll(limit, offset int64) (Policies, error) { return m.candidates, nil }
+
+func (m *fakeManager) FindRequestCandidates(ctx context.Context, r *Request) (Policies, error) {
+	return m.candidates, nil
+}
+
+func (m *fakeManager) Subscribe(events chan<- PolicyEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.subscribers = append(m.subscribers, events)
+}
+
+func (m *fakeManager) publish(event PolicyEvent) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for _, s := range m.subscribers {
+		s <- event
+	}
+}
+
+// fakeAuditLogger records every grant/reject it is asked to log. It is safe for concurrent
+// use: AreAllowed invokes the shared AuditLogger from multiple goroutines at once, and a real
+// implementation must tolerate that, so the fixture does too.
+type fakeAuditLogger struct {
+	mu       sync.Mutex
+	granted  int
+	rejected int
+}
+
+func (l *fakeAuditLogger) LogRejectedAccessRequest(ctx context.Context, r *Request, pool, deciders Policies) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.rejected++
+}
+
+func (l *fakeAuditLogger) LogGrantedAccessRequest(ctx context.Context, r *Request, pool, deciders Policies) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.granted++
+}
+
+func (l *fakeAuditLogger) grantedCount() int {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.granted
+}
+
+// fakeMetric is a no-op Metric; tests that need to observe specific calls embed it and
+// override the methods they care about.
+type fakeMetric struct{}
+
+func (fakeMetric) RequestDeniedBy(r Request, p Policy)               {}
+func (fakeMetric) RequestAllowedBy(r Request, deciders Policies)     {}
+func (fakeMetric) RequestNoMatch(r Request)                          {}
+func (fakeMetric) RequestProcessingError(r Request, p Policy, err error) {}
+
+// newTestLadon builds a Ladon wired with fakes sufficient to drive Decide/IsAllowed/
+// DoPoliciesAllow/AreAllowed in tests, without a real Manager or Matcher.
+//
+// Every field lazyInit would otherwise default is set explicitly here: lazyInit uses a
+// single package-level sync.Once, so only the very first Ladon constructed in the whole test
+// binary would actually get its defaults applied - every Ladon built by a test after that one
+// would see lazyInit as a no-op.
+func newTestLadon(candidates Policies) (*Ladon, *fakeManager) {
+	manager := &fakeManager{candidates: candidates}
+	l := &Ladon{
+		Manager:     manager,
+		Matcher:     &fakeMatcher{},
+		AuditLogger: &fakeAuditLogger{},
+		Metric:      fakeMetric{},
+		Combiner:    DenyOverrides,
+	}
+	return l, manager
+}
@@ -0,0 +1,88 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import "context"
+
+// ShadowMetric may be implemented by a Metric to receive notifications when the decision
+// computed against Ladon.ShadowManager diverges from the live decision.
+type ShadowMetric interface {
+	ShadowMismatch(r Request, live, shadow *Decision)
+}
+
+// ShadowAuditLogger may be implemented by an AuditLogger to record live/shadow divergences.
+type ShadowAuditLogger interface {
+	LogShadowMismatch(ctx context.Context, r *Request, live, shadow *Decision)
+}
+
+// evaluateShadow computes r's decision against l.ShadowManager, the "candidate" policy set,
+// and reports a divergence from the live decision via AuditLogger and Metric - provided they
+// implement ShadowAuditLogger / ShadowMetric - without ever affecting the decision returned
+// to the caller. It deliberately does not run the live AuditLogger/Metric hooks itself: only
+// a mismatch, not every shadow evaluation, is worth reporting. This lets operators roll out
+// policy changes with confidence by comparing how they would have decided production traffic
+// before making them live.
+func (l *Ladon) evaluateShadow(ctx context.Context, r *Request, live *Decision) {
+	if l.ShadowManager == nil {
+		return
+	}
+
+	policies, err := l.ShadowManager.FindRequestCandidates(ctx, r)
+	if err != nil {
+		return
+	}
+
+	matched, err := l.matchPolicies(ctx, r, policies)
+	if err != nil {
+		return
+	}
+
+	allowed, deciders, combineErr := l.Combiner.Combine(ctx, r, matched)
+	shadow := &Decision{
+		Deciders:    deciders,
+		Conditions:  l.evaluateDeciderConditions(ctx, r, deciders),
+		Obligations: collectObligations(deciders),
+		Advice:      map[string]interface{}{},
+	}
+	switch {
+	case combineErr != nil:
+		shadow.Effect = Deny
+	case !allowed:
+		if len(deciders) == 0 {
+			shadow.Effect = NotApplicable
+		} else {
+			shadow.Effect = Deny
+		}
+	default:
+		shadow.Effect = Permit
+	}
+
+	if shadow.Effect == live.Effect {
+		return
+	}
+
+	if sal, ok := l.AuditLogger.(ShadowAuditLogger); ok {
+		sal.LogShadowMismatch(ctx, r, live, shadow)
+	}
+	if sm, ok := l.Metric.(ShadowMetric); ok {
+		sm.ShadowMismatch(*r, live, shadow)
+	}
+}
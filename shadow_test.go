@@ -0,0 +1,125 @@
+/*
+ * Copyright © 2016-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ *
+ * @author		Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @copyright 	2015-2018 Aeneas Rekkas <aeneas+oss@aeneas.io>
+ * @license 	Apache-2.0
+ */
+
+package ladon
+
+import (
+	"context"
+	"testing"
+	"time"
+)
+
+// shadowAuditLogger embeds fakeAuditLogger and additionally implements ShadowAuditLogger.
+type shadowAuditLogger struct {
+	fakeAuditLogger
+	mismatches int
+}
+
+func (l *shadowAuditLogger) LogShadowMismatch(ctx context.Context, r *Request, live, shadow *Decision) {
+	l.mismatches++
+}
+
+// shadowMetric embeds fakeMetric and additionally implements ShadowMetric.
+type shadowMetric struct {
+	fakeMetric
+	mismatches int
+}
+
+func (m *shadowMetric) ShadowMismatch(r Request, live, shadow *Decision) {
+	m.mismatches++
+}
+
+func newShadowTestLadon(liveCandidates, shadowCandidates Policies) (*Ladon, *shadowAuditLogger, *shadowMetric) {
+	logger := &shadowAuditLogger{}
+	metric := &shadowMetric{}
+	l := &Ladon{
+		Manager:       &fakeManager{candidates: liveCandidates},
+		ShadowManager: &fakeManager{candidates: shadowCandidates},
+		Matcher:       &fakeMatcher{},
+		AuditLogger:   logger,
+		Metric:        metric,
+		Combiner:      DenyOverrides,
+	}
+	return l, logger, metric
+}
+
+func TestEvaluateShadowReportsDivergence(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	deny := &fakePolicy{ID: "2", Allow: false, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+
+	l, logger, metric := newShadowTestLadon(Policies{allow}, Policies{deny})
+
+	if _, err := l.Decide(context.Background(), &Request{Subject: "peter", Resource: "articles:1", Action: "read"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if logger.mismatches != 1 {
+		t.Fatalf("logger.mismatches = %d, want 1", logger.mismatches)
+	}
+	if metric.mismatches != 1 {
+		t.Fatalf("metric.mismatches = %d, want 1", metric.mismatches)
+	}
+}
+
+func TestEvaluateShadowDoesNotReportWhenDecisionsAgree(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+
+	l, logger, metric := newShadowTestLadon(Policies{allow}, Policies{allow})
+
+	if _, err := l.Decide(context.Background(), &Request{Subject: "peter", Resource: "articles:1", Action: "read"}); err != nil {
+		t.Fatalf("unexpected error: %v", err)
+	}
+
+	if logger.mismatches != 0 || metric.mismatches != 0 {
+		t.Fatalf("mismatches = %d/%d, want 0/0", logger.mismatches, metric.mismatches)
+	}
+}
+
+func TestEvaluateShadowIsANoopWithoutAShadowManager(t *testing.T) {
+	l, _ := newTestLadon(Policies{})
+
+	decision := &Decision{Effect: Permit}
+	l.evaluateShadow(context.Background(), &Request{}, decision)
+}
+
+// TestShadowDivergenceIsReportedOnCacheHitsToo guards against a DecisionCache silently
+// disabling shadow-mode detection in proportion to its hit rate: shadow evaluation must run
+// on every Decide call, not only on the cache miss that actually reaches decideFromManager.
+func TestShadowDivergenceIsReportedOnCacheHitsToo(t *testing.T) {
+	allow := &fakePolicy{ID: "1", Allow: true, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+	deny := &fakePolicy{ID: "2", Allow: false, Subjects: []string{"<.*>"}, Resources: []string{"<.*>"}, Actions: []string{"<.*>"}}
+
+	l, logger, metric := newShadowTestLadon(Policies{allow}, Policies{deny})
+	l.DecisionCache = NewLRUCache(10, time.Minute, nil, nil)
+
+	r := &Request{Subject: "peter", Resource: "articles:1", Action: "read"}
+	for i := 0; i < 2; i++ {
+		if _, err := l.Decide(context.Background(), r); err != nil {
+			t.Fatalf("unexpected error: %v", err)
+		}
+	}
+
+	if logger.mismatches != 2 {
+		t.Fatalf("logger.mismatches = %d, want 2 (one per Decide call, cache hit or not)", logger.mismatches)
+	}
+	if metric.mismatches != 2 {
+		t.Fatalf("metric.mismatches = %d, want 2", metric.mismatches)
+	}
+}